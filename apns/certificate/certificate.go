@@ -0,0 +1,94 @@
+// Package certificate loads the TLS certificates APNS authenticates push
+// connections with, so callers no longer have to wire up tls.Certificate
+// themselves before using the binary Client or the HTTP/2 provider.
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// oidUID is the UID attribute (0.9.2342.19200300.100.1.1) Apple stamps the
+// app's bundle ID into on push certificates.
+var oidUID = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}
+
+// Load reads the certificate at path, auto-detecting PKCS#12 (.p12/.pfx)
+// versus PEM (.pem) encoding from its file extension. password is only
+// used for PKCS#12 files.
+func Load(path, password string) (tls.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".p12", ".pfx":
+		return loadPKCS12(data, password)
+	case ".pem":
+		return tls.X509KeyPair(data, data)
+	default:
+		return tls.Certificate{}, errors.New("certificate: unrecognized certificate extension " + ext)
+	}
+}
+
+func loadPKCS12(data []byte, password string) (tls.Certificate, error) {
+	key, cert, err := pkcs12.Decode(data, password)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// TopicFromCertificate pulls the app's bundle ID out of cert's leaf
+// certificate, for use as the HTTP/2 apns-topic header. Apple encodes it
+// as the UID attribute of the subject, falling back to the common name.
+func TopicFromCertificate(cert tls.Certificate) (string, error) {
+	if len(cert.Certificate) == 0 {
+		return "", errors.New("certificate: no leaf certificate present")
+	}
+
+	leaf := cert.Leaf
+	if leaf == nil {
+		parsed, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return "", err
+		}
+		leaf = parsed
+	}
+
+	if uid := uidFromNames(leaf.Subject.Names); uid != "" {
+		return uid, nil
+	}
+	if leaf.Subject.CommonName != "" {
+		return leaf.Subject.CommonName, nil
+	}
+	return "", errors.New("certificate: no topic found in certificate subject")
+}
+
+func uidFromNames(names []pkix.AttributeTypeAndValue) string {
+	for _, name := range names {
+		if name.Type.Equal(oidUID) {
+			if s, ok := name.Value.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package certificate
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedPEM generates a throwaway self-signed certificate/key pair
+// with the given common name and writes it to a .pem file, returning its
+// path.
+func writeSelfSignedPEM(t *testing.T, commonName string) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).Add(24 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, append(certPEM, keyPEM...), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestLoadPEM(t *testing.T) {
+	path := writeSelfSignedPEM(t, "com.example.App")
+
+	cert, err := Load(path, "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cert.Certificate) == 0 {
+		t.Fatal("Load returned a certificate with no leaf")
+	}
+}
+
+func TestLoadUnrecognizedExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.crt")
+	if err := os.WriteFile(path, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := Load(path, ""); err == nil {
+		t.Fatal("expected an error for an unrecognized extension")
+	}
+}
+
+func TestTopicFromCertificate(t *testing.T) {
+	path := writeSelfSignedPEM(t, "com.example.App")
+
+	cert, err := Load(path, "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	topic, err := TopicFromCertificate(cert)
+	if err != nil {
+		t.Fatalf("TopicFromCertificate: %v", err)
+	}
+	if topic != "com.example.App" {
+		t.Fatalf("topic = %q, want %q", topic, "com.example.App")
+	}
+}
@@ -0,0 +1,246 @@
+package apns
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// The binary protocol's legacy gateways. APNS only ever writes back when it
+// rejects a notification, and then drops the connection, so Client keeps a
+// background reader running for as long as it's connected.
+const (
+	gatewayAddress        = "gateway.push.apple.com:2195"
+	gatewaySandboxAddress = "gateway.sandbox.push.apple.com:2195"
+)
+
+// How many recently sent envelopes Client keeps around so it can resend
+// everything after a failing identifier once it reconnects.
+const resendBufferSize = 1000
+
+// How many SendErrors Errors() buffers before the oldest undrained one is
+// dropped in favor of the newest. Errors() is telemetry, not a required
+// read loop, so publishing must never block the error reader.
+const errorBufferSize = 16
+
+// Status codes from the 6-byte error response frame: command uint8=8,
+// status uint8, identifier uint32.
+const (
+	STATUS_PROCESSING_ERROR     uint8 = 1
+	STATUS_MISSING_DEVICE_TOKEN uint8 = 2
+	STATUS_MISSING_TOPIC        uint8 = 3
+	STATUS_MISSING_PAYLOAD      uint8 = 4
+	STATUS_INVALID_TOKEN_SIZE   uint8 = 5
+	STATUS_INVALID_TOPIC_SIZE   uint8 = 6
+	STATUS_INVALID_PAYLOAD_SIZE uint8 = 7
+	STATUS_INVALID_TOKEN        uint8 = 8
+	STATUS_SHUTDOWN             uint8 = 10
+	STATUS_UNKNOWN              uint8 = 255
+)
+
+var statusMessages = map[uint8]string{
+	STATUS_PROCESSING_ERROR:     "processing error",
+	STATUS_MISSING_DEVICE_TOKEN: "missing device token",
+	STATUS_MISSING_TOPIC:        "missing topic",
+	STATUS_MISSING_PAYLOAD:      "missing payload",
+	STATUS_INVALID_TOKEN_SIZE:   "invalid token size",
+	STATUS_INVALID_TOPIC_SIZE:   "invalid topic size",
+	STATUS_INVALID_PAYLOAD_SIZE: "invalid payload size",
+	STATUS_INVALID_TOKEN:        "invalid token",
+	STATUS_SHUTDOWN:             "shutdown",
+	STATUS_UNKNOWN:              "unknown",
+}
+
+// SendError reports that APNS rejected the envelope with the given
+// Identifier, and why.
+type SendError struct {
+	Status     uint8
+	Identifier uint32
+}
+
+func (this *SendError) Error() string {
+	message, ok := statusMessages[this.Status]
+	if !ok {
+		message = statusMessages[STATUS_UNKNOWN]
+	}
+	return fmt.Sprintf("apns: notification %d rejected: %s", this.Identifier, message)
+}
+
+// Client is a persistent TLS connection to the binary gateway. Because the
+// gateway only ever responds with an error frame and then disconnects,
+// Client keeps a ring buffer of recently sent envelopes so it can
+// reconnect and resend everything sent after a failing identifier.
+type Client struct {
+	// dial opens a fresh connection to the gateway. Set by Connect to dial
+	// the real TLS gateway; tests substitute a net.Pipe-backed dialer to
+	// drive reconnect/resend without a certificate.
+	dial func() (net.Conn, error)
+
+	mu   sync.Mutex
+	conn net.Conn
+	sent []*Envelope
+
+	lastIdentifier uint32
+	errors         chan *SendError
+}
+
+// Connect dials the binary gateway (the sandbox one when sandbox is true)
+// using cert, and starts the background error reader.
+func (this *Client) Connect(cert tls.Certificate, sandbox bool) error {
+	addr := gatewayAddress
+	if sandbox {
+		addr = gatewaySandboxAddress
+	}
+	this.dial = func() (net.Conn, error) {
+		return tls.Dial("tcp", addr, &tls.Config{Certificates: []tls.Certificate{cert}})
+	}
+	if this.errors == nil {
+		this.errors = make(chan *SendError, errorBufferSize)
+	}
+	return this.connect()
+}
+
+func (this *Client) connect() error {
+	conn, err := this.dial()
+	if err != nil {
+		return err
+	}
+
+	this.mu.Lock()
+	this.conn = conn
+	this.mu.Unlock()
+
+	go this.readErrors(conn)
+	return nil
+}
+
+// Send writes env to the gateway, assigning it an Identifier first if it
+// doesn't already have one.
+func (this *Client) Send(env *Envelope) error {
+	this.mu.Lock()
+	if env.Identifier == 0 {
+		this.lastIdentifier++
+		env.Identifier = int32(this.lastIdentifier)
+	}
+	this.mu.Unlock()
+
+	if err := this.write(env); err != nil {
+		return err
+	}
+
+	this.remember(env)
+	return nil
+}
+
+// write encodes env and writes it to the current connection, without
+// touching the resend ring buffer. Used by Send, and by readErrors to
+// replay already-remembered envelopes after a reconnect.
+func (this *Client) write(env *Envelope) error {
+	this.mu.Lock()
+	conn := this.conn
+	this.mu.Unlock()
+
+	if conn == nil {
+		return errors.New("apns: client is not connected")
+	}
+
+	data, err := env.ToFrameBytes()
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(data)
+	return err
+}
+
+// Errors returns the channel SendErrors are published on as APNS rejects
+// notifications. Publishing never blocks: if a caller isn't draining this
+// channel, the oldest undrained SendError is dropped in favor of the
+// newest rather than stalling reconnects.
+func (this *Client) Errors() <-chan *SendError {
+	return this.errors
+}
+
+// publish delivers sendErr on this.errors without blocking, dropping the
+// oldest queued error to make room if the buffer is full.
+func (this *Client) publish(sendErr *SendError) {
+	select {
+	case this.errors <- sendErr:
+		return
+	default:
+	}
+
+	select {
+	case <-this.errors:
+	default:
+	}
+	select {
+	case this.errors <- sendErr:
+	default:
+	}
+}
+
+// Close shuts down the current connection. Send never reconnects by
+// itself, so a later Send fails until the client is connected again.
+func (this *Client) Close() error {
+	this.mu.Lock()
+	conn := this.conn
+	this.conn = nil
+	this.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+func (this *Client) remember(env *Envelope) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.sent = append(this.sent, env)
+	if len(this.sent) > resendBufferSize {
+		this.sent = this.sent[len(this.sent)-resendBufferSize:]
+	}
+}
+
+// envelopesAfter returns every remembered envelope sent strictly after
+// identifier, in the order they were sent.
+func (this *Client) envelopesAfter(identifier uint32) []*Envelope {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	result := make([]*Envelope, 0, len(this.sent))
+	for _, env := range this.sent {
+		if uint32(env.Identifier) > identifier {
+			result = append(result, env)
+		}
+	}
+	return result
+}
+
+// readErrors blocks for the single 6-byte error response frame APNS sends
+// on conn before dropping it, then reconnects and resends everything that
+// was sent after the failing identifier.
+func (this *Client) readErrors(conn net.Conn) {
+	buf := make([]byte, 6)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return
+	}
+
+	sendErr := &SendError{Status: buf[1], Identifier: binary.BigEndian.Uint32(buf[2:6])}
+	this.publish(sendErr)
+
+	resend := this.envelopesAfter(sendErr.Identifier)
+	conn.Close()
+	if err := this.connect(); err != nil {
+		return
+	}
+	for _, env := range resend {
+		if err := this.write(env); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package apns
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// readIdentifier reads a single enhanced binary frame off conn (Client
+// always writes one frame per conn.Write call) and returns the
+// notification identifier item it carries.
+func readIdentifier(t *testing.T, conn net.Conn) (uint32, bool) {
+	t.Helper()
+
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return 0, false
+	}
+
+	frame := buf[5:n]
+	for _, item := range parseItems(t, frame) {
+		if item.ID == ITEM_ID_NOTIFICATION_IDENTIFIER {
+			return binary.BigEndian.Uint32(item.Data), true
+		}
+	}
+	return 0, false
+}
+
+// collectIdentifiers drains identifiers off conn until it's closed or a
+// read fails, publishing each to the returned channel.
+func collectIdentifiers(t *testing.T, conn net.Conn) <-chan uint32 {
+	ids := make(chan uint32, 16)
+	go func() {
+		defer close(ids)
+		for {
+			id, ok := readIdentifier(t, conn)
+			if !ok {
+				return
+			}
+			ids <- id
+		}
+	}()
+	return ids
+}
+
+func TestClientReconnectsAndResendsAfterFailingIdentifier(t *testing.T) {
+	conns := make(chan net.Conn, 4)
+	c := &Client{
+		errors: make(chan *SendError, errorBufferSize),
+		dial: func() (net.Conn, error) {
+			server, client := net.Pipe()
+			conns <- server
+			return client, nil
+		},
+	}
+	if err := c.connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	server1 := <-conns
+	firstIDs := collectIdentifiers(t, server1)
+
+	for i := 0; i < 5; i++ {
+		env := &Envelope{DeviceToken: "0102"}
+		env.AddPayload(&Payload{Alert: "hi"})
+		if err := c.Send(env); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	for want := uint32(1); want <= 5; want++ {
+		select {
+		case got := <-firstIDs:
+			if got != want {
+				t.Fatalf("identifier = %d, want %d", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for identifier %d", want)
+		}
+	}
+
+	// Apple writes the 6-byte error frame for identifier 3 and drops the
+	// connection.
+	errFrame := make([]byte, 6)
+	errFrame[0] = 8
+	errFrame[1] = STATUS_INVALID_TOKEN
+	binary.BigEndian.PutUint32(errFrame[2:6], 3)
+	if _, err := server1.Write(errFrame); err != nil {
+		t.Fatalf("write error frame: %v", err)
+	}
+	server1.Close()
+
+	select {
+	case sendErr := <-c.Errors():
+		if sendErr.Identifier != 3 || sendErr.Status != STATUS_INVALID_TOKEN {
+			t.Fatalf("got %+v, want identifier 3 / status %d", sendErr, STATUS_INVALID_TOKEN)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the published SendError")
+	}
+
+	var server2 net.Conn
+	select {
+	case server2 = <-conns:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the client to reconnect")
+	}
+	secondIDs := collectIdentifiers(t, server2)
+
+	for _, want := range []uint32{4, 5} {
+		select {
+		case got := <-secondIDs:
+			if got != want {
+				t.Fatalf("resent identifier = %d, want %d", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for resent identifier %d", want)
+		}
+	}
+}
+
+func TestClientErrorsDoesNotBlockWhenUndrained(t *testing.T) {
+	c := &Client{errors: make(chan *SendError, errorBufferSize)}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := uint32(1); i <= errorBufferSize+1; i++ {
+			c.publish(&SendError{Identifier: i})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("publish blocked despite an undrained errors channel")
+	}
+
+	if n := len(c.errors); n != errorBufferSize {
+		t.Fatalf("buffered errors = %d, want %d", n, errorBufferSize)
+	}
+
+	// The oldest (identifier 1) should have been dropped to make room for
+	// the newest (identifier errorBufferSize+1).
+	first := <-c.errors
+	if first.Identifier != 2 {
+		t.Fatalf("oldest remaining identifier = %d, want 2", first.Identifier)
+	}
+	for i := 0; i < errorBufferSize-2; i++ {
+		<-c.errors
+	}
+	last := <-c.errors
+	if last.Identifier != errorBufferSize+1 {
+		t.Fatalf("newest remaining identifier = %d, want %d", last.Identifier, errorBufferSize+1)
+	}
+}
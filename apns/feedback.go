@@ -0,0 +1,130 @@
+package apns
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"time"
+)
+
+// The feedback service tells you which device tokens to stop sending to
+// because the app has been uninstalled.
+const (
+	feedbackAddress        = "feedback.push.apple.com:2196"
+	feedbackSandboxAddress = "feedback.sandbox.push.apple.com:2196"
+)
+
+// FeedbackTuple is a single record from the feedback service: the time the
+// device token was reported as expired, and the token itself.
+type FeedbackTuple struct {
+	Timestamp   time.Time
+	TokenLength uint16
+	DeviceToken string
+}
+
+// FeedbackClient streams expired device tokens from Apple's feedback
+// service so callers can prune tokens belonging to uninstalled apps.
+type FeedbackClient struct {
+	cert    tls.Certificate
+	sandbox bool
+}
+
+// NewFeedbackClient builds a FeedbackClient authenticated with cert,
+// talking to the sandbox feedback service when sandbox is true.
+func NewFeedbackClient(cert tls.Certificate, sandbox bool) *FeedbackClient {
+	return &FeedbackClient{cert: cert, sandbox: sandbox}
+}
+
+// Receive dials the feedback service and streams every tuple it sends
+// until it closes the connection, at which point both channels are
+// closed.
+func (this *FeedbackClient) Receive() (<-chan FeedbackTuple, <-chan error) {
+	tuples := make(chan FeedbackTuple)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(tuples)
+		defer close(errs)
+
+		addr := feedbackAddress
+		if this.sandbox {
+			addr = feedbackSandboxAddress
+		}
+		conn, err := tls.Dial("tcp", addr, &tls.Config{Certificates: []tls.Certificate{this.cert}})
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer conn.Close()
+
+		readFeedback(conn, tuples, errs)
+	}()
+
+	return tuples, errs
+}
+
+// readFeedback reads timestamp/tokenLength/token records from r until EOF
+// or a read error, publishing each as a FeedbackTuple.
+func readFeedback(r io.Reader, tuples chan<- FeedbackTuple, errs chan<- error) {
+	header := make([]byte, 6)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err != io.EOF {
+				errs <- err
+			}
+			return
+		}
+
+		timestamp := binary.BigEndian.Uint32(header[0:4])
+		tokenLength := binary.BigEndian.Uint16(header[4:6])
+
+		token := make([]byte, tokenLength)
+		if _, err := io.ReadFull(r, token); err != nil {
+			errs <- err
+			return
+		}
+
+		tuples <- FeedbackTuple{
+			Timestamp:   time.Unix(int64(timestamp), 0),
+			TokenLength: tokenLength,
+			DeviceToken: hex.EncodeToString(token),
+		}
+	}
+}
+
+// MockFeedbackServer replays a fixed set of tuples to a single connection
+// in the feedback service's wire format, so the record parsing behind
+// FeedbackClient can be exercised in tests without a real TLS certificate
+// or network access.
+// It speaks no TLS, and FeedbackClient.Receive always dials the real
+// service, so tests run readFeedback directly against Serve's peer
+// connection.
+type MockFeedbackServer struct {
+	Tuples []FeedbackTuple
+}
+
+// Serve writes every tuple to conn in wire format and then closes it.
+func (this *MockFeedbackServer) Serve(conn net.Conn) error {
+	defer conn.Close()
+
+	for _, tuple := range this.Tuples {
+		token, err := hex.DecodeString(tuple.DeviceToken)
+		if err != nil {
+			return err
+		}
+
+		header := make([]byte, 6)
+		binary.BigEndian.PutUint32(header[0:4], uint32(tuple.Timestamp.Unix()))
+		binary.BigEndian.PutUint16(header[4:6], uint16(len(token)))
+
+		if _, err := conn.Write(header); err != nil {
+			return err
+		}
+		if _, err := conn.Write(token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
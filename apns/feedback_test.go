@@ -0,0 +1,49 @@
+package apns
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReadFeedbackAgainstMockFeedbackServer(t *testing.T) {
+	want := []FeedbackTuple{
+		{Timestamp: time.Unix(1000, 0), DeviceToken: "0102030405060708"},
+		{Timestamp: time.Unix(2000, 0), DeviceToken: "aabbccddeeff0011"},
+	}
+
+	server, client := net.Pipe()
+	mock := &MockFeedbackServer{Tuples: want}
+	go mock.Serve(server)
+
+	tuples := make(chan FeedbackTuple)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(tuples)
+		defer close(errs)
+		readFeedback(client, tuples, errs)
+	}()
+
+	var got []FeedbackTuple
+	for tuple := range tuples {
+		got = append(got, tuple)
+	}
+	if err, ok := <-errs; ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d tuples, want %d", len(got), len(want))
+	}
+	for i, tuple := range got {
+		if !tuple.Timestamp.Equal(want[i].Timestamp) {
+			t.Fatalf("tuple %d timestamp = %v, want %v", i, tuple.Timestamp, want[i].Timestamp)
+		}
+		if tuple.DeviceToken != want[i].DeviceToken {
+			t.Fatalf("tuple %d device token = %s, want %s", i, tuple.DeviceToken, want[i].DeviceToken)
+		}
+		if int(tuple.TokenLength) != len(want[i].DeviceToken)/2 {
+			t.Fatalf("tuple %d token length = %d, want %d", i, tuple.TokenLength, len(want[i].DeviceToken)/2)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package apns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+// The enhanced binary notification format replaces PUSH_COMMAND_VALUE with
+// this command byte, followed by a 4-byte frame length and a sequence of
+// items rather than a fixed layout.
+const PUSH_FRAME_COMMAND_VALUE = 2
+
+// Item IDs for the enhanced binary format, in the order Apple documents
+// them.
+const (
+	ITEM_ID_DEVICE_TOKEN            uint8 = 1
+	ITEM_ID_PAYLOAD                 uint8 = 2
+	ITEM_ID_NOTIFICATION_IDENTIFIER uint8 = 3
+	ITEM_ID_EXPIRATION_DATE         uint8 = 4
+	ITEM_ID_PRIORITY                uint8 = 5
+)
+
+// Valid values for Envelope.Priority.
+const (
+	PRIORITY_IMMEDIATE        uint8 = 10
+	PRIORITY_POWER_CONSERVING uint8 = 5
+)
+
+// Item is a single itemID/itemLength/data tuple within a frame.
+type Item struct {
+	ID   uint8
+	Data []byte
+}
+
+// Bytes encodes the item as itemID uint8, itemLength uint16, data []byte.
+func (this Item) Bytes() []byte {
+	buffer := bytes.NewBuffer([]byte{})
+	binary.Write(buffer, binary.BigEndian, this.ID)
+	binary.Write(buffer, binary.BigEndian, uint16(len(this.Data)))
+	binary.Write(buffer, binary.BigEndian, this.Data)
+	return buffer.Bytes()
+}
+
+// ToFrameBytes returns the Envelope encoded as Apple's enhanced binary
+// notification format (command 2): a 4-byte frame length followed by the
+// device token, payload, notification identifier, expiration date and,
+// when set, priority items, in that order. Use ToBytes() instead for the
+// legacy command 1 format.
+func (this *Envelope) ToFrameBytes() ([]byte, error) {
+	token, err := hex.DecodeString(this.DeviceToken)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := this.PayloadJSON()
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > MAX_PAYLOAD_SIZE_BYTES {
+		return nil, errors.New("payload is larger than the " + strconv.Itoa(MAX_PAYLOAD_SIZE_BYTES) + " byte limit")
+	}
+
+	identifier := bytes.NewBuffer([]byte{})
+	binary.Write(identifier, binary.BigEndian, uint32(this.Identifier))
+
+	expiration := bytes.NewBuffer([]byte{})
+	binary.Write(expiration, binary.BigEndian, uint32(this.Expiry))
+
+	items := []Item{
+		{ID: ITEM_ID_DEVICE_TOKEN, Data: token},
+		{ID: ITEM_ID_PAYLOAD, Data: payload},
+		{ID: ITEM_ID_NOTIFICATION_IDENTIFIER, Data: identifier.Bytes()},
+		{ID: ITEM_ID_EXPIRATION_DATE, Data: expiration.Bytes()},
+	}
+	if this.Priority != 0 {
+		items = append(items, Item{ID: ITEM_ID_PRIORITY, Data: []byte{this.Priority}})
+	}
+
+	frame := bytes.NewBuffer([]byte{})
+	for _, item := range items {
+		frame.Write(item.Bytes())
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	binary.Write(buffer, binary.BigEndian, uint8(PUSH_FRAME_COMMAND_VALUE))
+	binary.Write(buffer, binary.BigEndian, uint32(frame.Len()))
+	buffer.Write(frame.Bytes())
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,85 @@
+package apns
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// parseItems walks a frame's body (everything after the command byte and
+// frame length) and returns its items in order.
+func parseItems(t *testing.T, frame []byte) []Item {
+	t.Helper()
+
+	var items []Item
+	for len(frame) > 0 {
+		if len(frame) < 3 {
+			t.Fatalf("truncated item header: %x", frame)
+		}
+		id := frame[0]
+		length := binary.BigEndian.Uint16(frame[1:3])
+		data := frame[3 : 3+int(length)]
+		items = append(items, Item{ID: id, Data: append([]byte{}, data...)})
+		frame = frame[3+int(length):]
+	}
+	return items
+}
+
+func TestEnvelopeToFrameBytes(t *testing.T) {
+	env := &Envelope{Identifier: 9, Expiry: 123, DeviceToken: "0102", Priority: PRIORITY_IMMEDIATE}
+	env.AddPayload(&Payload{Alert: "hi"})
+
+	data, err := env.ToFrameBytes()
+	if err != nil {
+		t.Fatalf("ToFrameBytes: %v", err)
+	}
+
+	if data[0] != PUSH_FRAME_COMMAND_VALUE {
+		t.Fatalf("command = %d, want %d", data[0], PUSH_FRAME_COMMAND_VALUE)
+	}
+	frameLen := binary.BigEndian.Uint32(data[1:5])
+	frame := data[5:]
+	if int(frameLen) != len(frame) {
+		t.Fatalf("frame length = %d, want %d", frameLen, len(frame))
+	}
+
+	items := parseItems(t, frame)
+	if len(items) != 5 {
+		t.Fatalf("got %d items, want 5", len(items))
+	}
+
+	wantIDs := []uint8{ITEM_ID_DEVICE_TOKEN, ITEM_ID_PAYLOAD, ITEM_ID_NOTIFICATION_IDENTIFIER, ITEM_ID_EXPIRATION_DATE, ITEM_ID_PRIORITY}
+	for i, item := range items {
+		if item.ID != wantIDs[i] {
+			t.Fatalf("item %d has ID %d, want %d", i, item.ID, wantIDs[i])
+		}
+	}
+
+	if hex.EncodeToString(items[0].Data) != "0102" {
+		t.Fatalf("device token item = %x, want 0102", items[0].Data)
+	}
+	if id := binary.BigEndian.Uint32(items[2].Data); id != 9 {
+		t.Fatalf("identifier item = %d, want 9", id)
+	}
+	if expiry := binary.BigEndian.Uint32(items[3].Data); expiry != 123 {
+		t.Fatalf("expiration item = %d, want 123", expiry)
+	}
+	if len(items[4].Data) != 1 || items[4].Data[0] != PRIORITY_IMMEDIATE {
+		t.Fatalf("priority item = %v, want [%d]", items[4].Data, PRIORITY_IMMEDIATE)
+	}
+}
+
+func TestEnvelopeToFrameBytesOmitsPriorityWhenUnset(t *testing.T) {
+	env := &Envelope{DeviceToken: "0102"}
+	env.AddPayload(&Payload{Alert: "hi"})
+
+	data, err := env.ToFrameBytes()
+	if err != nil {
+		t.Fatalf("ToFrameBytes: %v", err)
+	}
+
+	items := parseItems(t, data[5:])
+	if len(items) != 4 {
+		t.Fatalf("got %d items, want 4 (no priority item)", len(items))
+	}
+}
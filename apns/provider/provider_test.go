@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sevki/apns/apns"
+)
+
+func TestProviderPushReturnsApnsID(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/3/device/abc123" {
+			t.Errorf("path = %s, want /3/device/abc123", r.URL.Path)
+		}
+		if got := r.Header.Get("apns-topic"); got != "com.example.App" {
+			t.Errorf("apns-topic header = %q, want %q", got, "com.example.App")
+		}
+		w.Header().Set("apns-id", "a-generated-id")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	p := &Provider{service: Service(ts.URL), client: ts.Client()}
+
+	apnsID, err := p.Push("abc123", &Headers{ApnsTopic: "com.example.App"}, []byte(`{"aps":{"alert":"hi"}}`))
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if apnsID != "a-generated-id" {
+		t.Fatalf("apnsID = %q, want %q", apnsID, "a-generated-id")
+	}
+}
+
+func TestProviderPushDecodesErrorBody(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"reason":    "BadDeviceToken",
+			"timestamp": 1454948015990,
+		})
+	}))
+	defer ts.Close()
+
+	p := &Provider{service: Service(ts.URL), client: ts.Client()}
+
+	_, err := p.Push("abc123", nil, []byte(`{"aps":{"alert":"hi"}}`))
+	if err == nil {
+		t.Fatal("expected an error for a 410 response")
+	}
+	pushErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("error type = %T, want *Error", err)
+	}
+	if pushErr.Status != http.StatusGone || pushErr.Reason != "BadDeviceToken" {
+		t.Fatalf("got %+v, want status %d / reason BadDeviceToken", pushErr, http.StatusGone)
+	}
+}
+
+func TestProviderPushRejectsOversizedPayload(t *testing.T) {
+	// Local size validation happens before any network call, so these
+	// don't need a real or test server.
+	p := &Provider{service: Development, client: http.DefaultClient}
+
+	if _, err := p.Push("abc123", nil, make([]byte, MaxPayloadSizeAlert+1)); err == nil {
+		t.Fatal("expected an error for an alert payload over the 4096-byte cap")
+	}
+	if _, err := p.Push("abc123", &Headers{ApnsPushType: PushTypeVoIP}, make([]byte, MaxPayloadSizeVoIP+1)); err == nil {
+		t.Fatal("expected an error for a VoIP payload over the 5120-byte cap")
+	}
+}
+
+func TestProviderPushAllowsVoIPPayloadOverAlertCap(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	p := &Provider{service: Service(ts.URL), client: ts.Client()}
+
+	// Larger than the alert cap but within the VoIP cap: should reach the
+	// server rather than being rejected locally.
+	_, err := p.Push("abc123", &Headers{ApnsPushType: PushTypeVoIP}, make([]byte, MaxPayloadSizeAlert+1))
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+}
+
+func TestProviderPushNotification(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	p := &Provider{service: Service(ts.URL), client: ts.Client()}
+	badge := 3
+	_, err := p.PushNotification(&Notification{
+		DeviceToken: "abc123",
+		Payload:     &apns.Payload{Alert: "hi", Badge: &badge},
+	})
+	if err != nil {
+		t.Fatalf("PushNotification: %v", err)
+	}
+
+	aps, ok := body["aps"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("request body = %v, want an \"aps\" object", body)
+	}
+	if aps["alert"] != "hi" || aps["badge"] != float64(3) {
+		t.Fatalf("aps = %v, want alert=hi badge=3", aps)
+	}
+}
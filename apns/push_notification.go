@@ -23,10 +23,61 @@ const PUSH_COMMAND_VALUE = 1
 // The total length of the payload cannot exceed this amount.
 const MAX_PAYLOAD_SIZE_BYTES = 256
 
+// Payload is the "aps" dictionary. Badge is a *int because a real badge
+// count of 0 (clearing the badge) and "don't touch the badge" both have to
+// be expressible; MarshalJSON relies on that to omit it correctly.
 type Payload struct {
-	Alert interface{} `json:"alert,omitempty"`
-	Badge int         `json:"badge,omitempty"`
-	Sound string      `json:"sound,omitempty"`
+	Alert            interface{}
+	Badge            *int
+	Sound            string
+	ContentAvailable int      // 1 for a silent, content-available push
+	MutableContent   int      // 1 to route through a notification service extension
+	Category         string   // the action category to display with the alert
+	ThreadID         string   // groups related notifications together
+	URLArgs          []string // substitutions for a Safari website push's URL format string
+}
+
+// MarshalJSON builds the "aps" dictionary by hand so zero-valued numeric
+// fields are omitted correctly: Badge is only sent when set (including
+// badge 0, to clear it), and ContentAvailable/MutableContent are only sent
+// when they hold Apple's required value of 1.
+func (this Payload) MarshalJSON() ([]byte, error) {
+	aps := make(map[string]interface{})
+	if this.Alert != nil {
+		aps["alert"] = this.Alert
+	}
+	if this.Badge != nil {
+		aps["badge"] = *this.Badge
+	}
+	if this.Sound != "" {
+		aps["sound"] = this.Sound
+	}
+	if this.ContentAvailable == 1 {
+		aps["content-available"] = 1
+	}
+	if this.MutableContent == 1 {
+		aps["mutable-content"] = 1
+	}
+	if this.Category != "" {
+		aps["category"] = this.Category
+	}
+	if this.ThreadID != "" {
+		aps["thread-id"] = this.ThreadID
+	}
+	if len(this.URLArgs) > 0 {
+		aps["url-args"] = this.URLArgs
+	}
+	return json.Marshal(aps)
+}
+
+// Validate enforces the mutual-exclusion rules Apple documents for "aps"
+// dictionaries, e.g. a pure background push (content-available: 1) must
+// not also carry a user-visible alert, sound, or badge.
+func (this *Payload) Validate() error {
+	if this.ContentAvailable == 1 && (this.Alert != nil || this.Sound != "" || this.Badge != nil) {
+		return errors.New("apns: content-available payload must not also set alert, sound, or badge")
+	}
+	return nil
 }
 
 // From the APN documentation:
@@ -37,6 +88,73 @@ type AlertDictionary struct {
 	LocKey       string   `json:"loc-key,omitempty"`
 	LocArgs      []string `json:"loc-args,omitempty"`
 	LaunchImage  string   `json:"launch-image,omitempty"`
+	Subtitle     string   `json:"subtitle,omitempty"`
+	TitleLocKey  string   `json:"title-loc-key,omitempty"`
+	TitleLocArgs []string `json:"title-loc-args,omitempty"`
+}
+
+// PayloadBuilder builds a Payload one field at a time and validates it on
+// Build(), so callers don't have to know Apple's mutual-exclusion rules
+// up front.
+type PayloadBuilder struct {
+	payload Payload
+}
+
+// NewPayloadBuilder returns an empty PayloadBuilder.
+func NewPayloadBuilder() *PayloadBuilder {
+	return &PayloadBuilder{}
+}
+
+func (this *PayloadBuilder) Alert(alert interface{}) *PayloadBuilder {
+	this.payload.Alert = alert
+	return this
+}
+
+func (this *PayloadBuilder) Badge(badge int) *PayloadBuilder {
+	this.payload.Badge = &badge
+	return this
+}
+
+func (this *PayloadBuilder) Sound(sound string) *PayloadBuilder {
+	this.payload.Sound = sound
+	return this
+}
+
+// ContentAvailable marks the payload as a silent, background push.
+func (this *PayloadBuilder) ContentAvailable() *PayloadBuilder {
+	this.payload.ContentAvailable = 1
+	return this
+}
+
+// MutableContent routes the payload through a notification service
+// extension before it's displayed.
+func (this *PayloadBuilder) MutableContent() *PayloadBuilder {
+	this.payload.MutableContent = 1
+	return this
+}
+
+func (this *PayloadBuilder) Category(category string) *PayloadBuilder {
+	this.payload.Category = category
+	return this
+}
+
+func (this *PayloadBuilder) ThreadID(threadID string) *PayloadBuilder {
+	this.payload.ThreadID = threadID
+	return this
+}
+
+func (this *PayloadBuilder) URLArgs(args ...string) *PayloadBuilder {
+	this.payload.URLArgs = args
+	return this
+}
+
+// Build validates the payload built so far and returns it.
+func (this *PayloadBuilder) Build() (*Payload, error) {
+	if err := this.payload.Validate(); err != nil {
+		return nil, err
+	}
+	payload := this.payload
+	return &payload, nil
 }
 
 // The Envelope is the overall wrapper for the various push notification fields.
@@ -47,6 +165,10 @@ type Envelope struct {
 	Expiry      uint32
 	DeviceToken string
 
+	// Priority is only honoured by the enhanced binary format produced by
+	// ToFrameBytes(); it is ignored by the legacy ToBytes() command.
+	Priority uint8
+
 	payload map[string]interface{}
 }
 
@@ -0,0 +1,110 @@
+package apns
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func TestEnvelopeToBytes(t *testing.T) {
+	env := &Envelope{Identifier: 7, Expiry: 42, DeviceToken: "00010203"}
+	env.AddPayload(&Payload{Alert: "hello"})
+
+	data, err := env.ToBytes()
+	if err != nil {
+		t.Fatalf("ToBytes: %v", err)
+	}
+
+	if data[0] != PUSH_COMMAND_VALUE {
+		t.Fatalf("command = %d, want %d", data[0], PUSH_COMMAND_VALUE)
+	}
+	if id := binary.BigEndian.Uint32(data[1:5]); id != 7 {
+		t.Fatalf("identifier = %d, want 7", id)
+	}
+	if expiry := binary.BigEndian.Uint32(data[5:9]); expiry != 42 {
+		t.Fatalf("expiry = %d, want 42", expiry)
+	}
+
+	tokenLen := binary.BigEndian.Uint16(data[9:11])
+	token := data[11 : 11+int(tokenLen)]
+	if hex.EncodeToString(token) != "00010203" {
+		t.Fatalf("device token = %x, want 00010203", token)
+	}
+
+	rest := data[11+int(tokenLen):]
+	payloadLen := binary.BigEndian.Uint16(rest[0:2])
+	payload := rest[2 : 2+int(payloadLen)]
+
+	var decoded struct {
+		Aps struct {
+			Alert string `json:"alert"`
+		} `json:"aps"`
+	}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if decoded.Aps.Alert != "hello" {
+		t.Fatalf("aps.alert = %q, want %q", decoded.Aps.Alert, "hello")
+	}
+}
+
+func TestEnvelopeToBytesPayloadTooLarge(t *testing.T) {
+	env := &Envelope{DeviceToken: "00010203"}
+	env.Set("aps", make([]byte, MAX_PAYLOAD_SIZE_BYTES))
+
+	if _, err := env.ToBytes(); err == nil {
+		t.Fatal("expected an error for an oversized payload, got nil")
+	}
+}
+
+func TestPayloadMarshalJSON(t *testing.T) {
+	zero := 0
+	p := Payload{Badge: &zero}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"badge":0}` {
+		t.Fatalf("got %s, want badge:0 to be sent so the badge can be cleared", data)
+	}
+
+	data, err = json.Marshal(Payload{})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{}` {
+		t.Fatalf("got %s, want an empty aps dictionary when nothing is set", data)
+	}
+
+	data, err = json.Marshal(Payload{ContentAvailable: 1})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"content-available":1}` {
+		t.Fatalf("got %s, want content-available:1", data)
+	}
+}
+
+func TestPayloadValidate(t *testing.T) {
+	if err := (&Payload{ContentAvailable: 1, Alert: "hi"}).Validate(); err == nil {
+		t.Fatal("expected content-available with alert to fail validation")
+	}
+	if err := (&Payload{ContentAvailable: 1}).Validate(); err != nil {
+		t.Fatalf("pure background push should validate, got %v", err)
+	}
+}
+
+func TestPayloadBuilder(t *testing.T) {
+	if _, err := NewPayloadBuilder().ContentAvailable().Sound("default").Build(); err == nil {
+		t.Fatal("expected Build to reject a content-available payload with a sound")
+	}
+
+	payload, err := NewPayloadBuilder().Alert("hi").Badge(3).Category("invite").Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if payload.Alert != "hi" || payload.Badge == nil || *payload.Badge != 3 || payload.Category != "invite" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}